@@ -70,7 +70,7 @@ type MetricConfig struct {
 	 * the max count of error code recorded by Compass. The default value is 100
 	 */
 	MaxCompassErrorCodeCount int `yaml:"max_compass_error_code_count" json:"max_compass_error_code_count,omitempty"`
-	MaxCompassAddonCount     int `yaml:"max_metric_count_per_registry" json:"max_metric_count_per_registry,omitempty"`
+	MaxCompassAddonCount     int `yaml:"max_compass_addon_count" json:"max_compass_addon_count,omitempty"`
 }
 
 func (mc *MetricConfig) GetMaxCompassAddonCount() int {
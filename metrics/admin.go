@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+)
+
+import (
+	"github.com/apache/dubbo-go/registry"
+)
+
+// InstanceSnapshotProvider is satisfied by any registry.ServiceDiscovery implementation
+// (file, etcd, nacos, ...) and is the source the admin dump endpoint reads its snapshot from.
+type InstanceSnapshotProvider interface {
+	GetServices() *gxset.HashSet
+	GetInstances(serviceName string) []registry.ServiceInstance
+}
+
+// AdminDumpHandler returns an http.HandlerFunc that serves a JSON snapshot, grouped by
+// ServiceName, of every instance currently known to providers.
+func AdminDumpHandler(providers ...InstanceSnapshotProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		snapshot := make(map[string][]registry.ServiceInstance)
+		for _, p := range providers {
+			for _, v := range p.GetServices().Values() {
+				serviceName := v.(string)
+				snapshot[serviceName] = append(snapshot[serviceName], p.GetInstances(serviceName)...)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/constant"
+	"github.com/apache/dubbo-go/common/logger"
+	"github.com/apache/dubbo-go/config"
+)
+
+func init() {
+	SetMetricsManager(constant.DEFAULT_KEY, newMemoryMetricsManager)
+}
+
+// memoryMetricsManager is the default MetricsManager: an in-memory store bounded by
+// config.MetricConfig's MaxMetricCountPerRegistry and MaxCompassErrorCodeCount knobs.
+type memoryMetricsManager struct {
+	mu sync.RWMutex
+
+	maxMetricCountPerRegistry int
+	maxCompassErrorCodeCount  int
+
+	// metrics holds a bounded, oldest-first ring of samples per service name.
+	metrics map[string][]Metric
+	// errorCodes holds the distinct error codes seen per service name, bounded by
+	// maxCompassErrorCodeCount.
+	errorCodes map[string]map[string]struct{}
+}
+
+func newMemoryMetricsManager() MetricsManager {
+	mc := config.GetMetricConfig()
+	return &memoryMetricsManager{
+		maxMetricCountPerRegistry: mc.GetMaxMetricCountPerRegistry(),
+		maxCompassErrorCodeCount:  mc.GetMaxCompassErrorCodeCount(),
+		metrics:                   make(map[string][]Metric),
+		errorCodes:                make(map[string]map[string]struct{}),
+	}
+}
+
+// Register records a single measurement. Once the service holds maxMetricCountPerRegistry
+// samples, the oldest one is evicted to make room; an error code that would exceed the
+// service's maxCompassErrorCodeCount distinct codes is dropped.
+func (m *memoryMetricsManager) Register(metric Metric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if metric.Type == MetricTypeErrorCode {
+		codes, ok := m.errorCodes[metric.ServiceName]
+		if !ok {
+			codes = make(map[string]struct{})
+			m.errorCodes[metric.ServiceName] = codes
+		}
+		if _, seen := codes[metric.Key]; !seen && len(codes) >= m.maxCompassErrorCodeCount {
+			logger.Warnf("[MetricsManager] dropping error code{%s} for service{%s}: "+
+				"max_compass_error_code_count{%d} reached", metric.Key, metric.ServiceName, m.maxCompassErrorCodeCount)
+			return
+		}
+		codes[metric.Key] = struct{}{}
+	}
+
+	bucket := m.metrics[metric.ServiceName]
+	if len(bucket) >= m.maxMetricCountPerRegistry {
+		bucket = bucket[1:]
+	}
+	m.metrics[metric.ServiceName] = append(bucket, metric)
+}
+
+// Report returns a copy of the measurements currently held for serviceName.
+func (m *memoryMetricsManager) Report(serviceName string) []Metric {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := make([]Metric, len(m.metrics[serviceName]))
+	copy(res, m.metrics[serviceName])
+	return res
+}
+
+// List returns a copy of the measurements currently held for every tracked service.
+func (m *memoryMetricsManager) List() map[string][]Metric {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := make(map[string][]Metric, len(m.metrics))
+	for serviceName, bucket := range m.metrics {
+		cp := make([]Metric, len(bucket))
+		copy(cp, bucket)
+		res[serviceName] = cp
+	}
+	return res
+}
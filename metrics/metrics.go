@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics is the subsystem backing config.MetricConfig: it lets the filter chain,
+// registry and protocol layers report runtime measurements, and exposes them for consumption
+// through a Prometheus exposition endpoint and an admin dump endpoint.
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// MetricType identifies the kind of measurement a Metric carries.
+type MetricType int
+
+const (
+	// MetricTypeRT is a single response-time sample, in milliseconds.
+	MetricTypeRT MetricType = iota
+	// MetricTypeQPS is a single request sample, used to derive queries-per-second.
+	MetricTypeQPS
+	// MetricTypeErrorCode is a single occurrence of a protocol/business error code.
+	MetricTypeErrorCode
+	// MetricTypeInstanceCount is a point-in-time count of instances for a service.
+	MetricTypeInstanceCount
+)
+
+// Metric is a single measurement reported against a service.
+type Metric struct {
+	ServiceName string
+	Type        MetricType
+	// Key further qualifies Type, e.g. the error code for MetricTypeErrorCode. It is
+	// empty for metric types that don't need it.
+	Key       string
+	Value     float64
+	Timestamp time.Time
+}
+
+// MetricsManager is the extension point that the filter chain, registry and protocol layers
+// feed RT/QPS/error-code/instance-count measurements into, and that exporters (Prometheus,
+// the admin dump endpoint) read back from.
+type MetricsManager interface {
+	// Register records a single measurement.
+	Register(metric Metric)
+	// Report returns the measurements currently held for serviceName.
+	Report(serviceName string) []Metric
+	// List returns the measurements currently held for every tracked service, keyed by
+	// ServiceName.
+	List() map[string][]Metric
+}
+
+var (
+	managers    = make(map[string]func() MetricsManager, 4)
+	managerLock sync.Mutex
+)
+
+// SetMetricsManager registers a MetricsManager factory under name so it can later be
+// retrieved with GetMetricsManager, e.g. from config.MetricConfig.GetMetricManagerName().
+func SetMetricsManager(name string, fcn func() MetricsManager) {
+	managerLock.Lock()
+	defer managerLock.Unlock()
+	managers[name] = fcn
+}
+
+// GetMetricsManager returns a new instance of the MetricsManager registered under name.
+func GetMetricsManager(name string) (MetricsManager, error) {
+	managerLock.Lock()
+	fcn, ok := managers[name]
+	managerLock.Unlock()
+
+	if !ok {
+		return nil, perrors.Errorf("could not find the metrics manager for name: %s", name)
+	}
+	return fcn(), nil
+}
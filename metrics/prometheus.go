@@ -0,0 +1,159 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/apache/dubbo-go/config"
+)
+
+// prometheusContentType is the exposition format served by PrometheusExporter.
+const prometheusContentType = "text/plain; version=0.0.4"
+
+// PrometheusExporter renders the metrics held by a MetricsManager into the Prometheus text
+// exposition format, refreshing the snapshot every config.MetricConfig.GlobalInterval and
+// serving it over HTTP.
+type PrometheusExporter struct {
+	manager  MetricsManager
+	interval time.Duration
+	snapshot atomic.Value // string
+	stopCh   chan struct{}
+}
+
+// NewPrometheusExporter builds an exporter over manager, refreshed at the interval configured
+// by config.GetMetricConfig().GetGlobalInterval().
+func NewPrometheusExporter(manager MetricsManager) *PrometheusExporter {
+	e := &PrometheusExporter{
+		manager:  manager,
+		interval: config.GetMetricConfig().GetGlobalInterval(),
+		stopCh:   make(chan struct{}),
+	}
+	e.snapshot.Store("")
+	return e
+}
+
+// Start renders an initial snapshot and launches the background refresh loop. Call Stop to
+// end it.
+func (e *PrometheusExporter) Start() {
+	e.refresh()
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.refresh()
+			case <-e.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background refresh loop.
+func (e *PrometheusExporter) Stop() {
+	close(e.stopCh)
+}
+
+// rtSummary accumulates the response-time samples seen for a service into the count/sum
+// pair a Prometheus summary needs.
+type rtSummary struct {
+	count int64
+	sum   float64
+}
+
+// errorCodeKey identifies one error-code counter series.
+type errorCodeKey struct {
+	serviceName string
+	code        string
+}
+
+// refresh aggregates the raw samples currently held by the MetricsManager into one series
+// per service (and, for error codes, per service+code) and renders them as a valid
+// Prometheus scrape: every metric name gets a single HELP/TYPE header and at most one line
+// per label set, as the exposition format requires.
+func (e *PrometheusExporter) refresh() {
+	rt := make(map[string]*rtSummary)
+	qps := make(map[string]float64)
+	errorCodes := make(map[errorCodeKey]int64)
+	instanceCount := make(map[string]float64)
+
+	for serviceName, ms := range e.manager.List() {
+		for _, m := range ms {
+			switch m.Type {
+			case MetricTypeRT:
+				s, ok := rt[serviceName]
+				if !ok {
+					s = &rtSummary{}
+					rt[serviceName] = s
+				}
+				s.count++
+				s.sum += m.Value
+			case MetricTypeQPS:
+				qps[serviceName] += m.Value
+			case MetricTypeErrorCode:
+				errorCodes[errorCodeKey{serviceName: serviceName, code: m.Key}]++
+			case MetricTypeInstanceCount:
+				// a point-in-time count: the latest sample wins, it isn't summed
+				instanceCount[serviceName] = m.Value
+			}
+		}
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("# HELP dubbo_rt_milliseconds Response time, in milliseconds.\n")
+	sb.WriteString("# TYPE dubbo_rt_milliseconds summary\n")
+	for serviceName, s := range rt {
+		fmt.Fprintf(&sb, "dubbo_rt_milliseconds_count{service=%q} %d\n", serviceName, s.count)
+		fmt.Fprintf(&sb, "dubbo_rt_milliseconds_sum{service=%q} %v\n", serviceName, s.sum)
+	}
+
+	sb.WriteString("# HELP dubbo_requests_total Total number of requests observed.\n")
+	sb.WriteString("# TYPE dubbo_requests_total counter\n")
+	for serviceName, v := range qps {
+		fmt.Fprintf(&sb, "dubbo_requests_total{service=%q} %v\n", serviceName, v)
+	}
+
+	sb.WriteString("# HELP dubbo_error_code_total Total occurrences of an error code.\n")
+	sb.WriteString("# TYPE dubbo_error_code_total counter\n")
+	for key, count := range errorCodes {
+		fmt.Fprintf(&sb, "dubbo_error_code_total{service=%q,code=%q} %d\n", key.serviceName, key.code, count)
+	}
+
+	sb.WriteString("# HELP dubbo_service_instance_count Current number of instances for a service.\n")
+	sb.WriteString("# TYPE dubbo_service_instance_count gauge\n")
+	for serviceName, v := range instanceCount {
+		fmt.Fprintf(&sb, "dubbo_service_instance_count{service=%q} %v\n", serviceName, v)
+	}
+
+	e.snapshot.Store(sb.String())
+}
+
+// ServeHTTP implements http.Handler, serving the most recently rendered snapshot.
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	fmt.Fprint(w, e.snapshot.Load().(string))
+}
@@ -0,0 +1,62 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net"
+	"net/http"
+)
+
+import (
+	"github.com/apache/dubbo-go/common/logger"
+)
+
+const (
+	// PrometheusPath is the path the Prometheus exposition endpoint is mounted at by StartServer.
+	PrometheusPath = "/metrics"
+	// AdminDumpPath is the path the admin instance-dump endpoint is mounted at by StartServer.
+	AdminDumpPath = "/admin/metrics/dump"
+)
+
+// StartServer starts manager's Prometheus exporter and serves it, alongside the admin dump
+// endpoint over providers, on a new http.Server listening at addr. This is the "somewhere
+// reachable" a ServiceDiscovery implementation mounts the metrics subsystem from, since this
+// module has no bootstrap/main of its own to start it centrally. Callers own the returned
+// server and exporter and are responsible for Close/Stop during Destroy.
+func StartServer(addr string, manager MetricsManager, providers ...InstanceSnapshotProvider) (*http.Server, *PrometheusExporter, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exporter := NewPrometheusExporter(manager)
+	exporter.Start()
+
+	mux := http.NewServeMux()
+	mux.Handle(PrometheusPath, exporter)
+	mux.Handle(AdminDumpPath, AdminDumpHandler(providers...))
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("[metrics] server on addr{%s} stopped serving, error = err{%v}", addr, err)
+		}
+	}()
+
+	return server, exporter, nil
+}
@@ -0,0 +1,61 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/apache/dubbo-go/registry"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestDiscoveryWithWatcher builds a fileSystemServiceDiscovery whose groupWatchers already
+// has an entry for serviceName, so AddListener reuses that watcher instead of subscribing a new
+// group and never touches dynamicConfiguration.
+func newTestDiscoveryWithWatcher(serviceName string) *fileSystemServiceDiscovery {
+	return &fileSystemServiceDiscovery{
+		listeners:     make(map[string][]*registry.ServiceInstancesChangedListener),
+		groupWatchers: map[string]*RegistryConfigurationListener{serviceName: {}},
+	}
+}
+
+func TestAddListener_DedupsBySameListenerPointer(t *testing.T) {
+	serviceName := "com.foo.Bar"
+	fssd := newTestDiscoveryWithWatcher(serviceName)
+	l := &registry.ServiceInstancesChangedListener{ServiceName: serviceName}
+
+	assert.NoError(t, fssd.AddListener(l))
+	assert.NoError(t, fssd.AddListener(l))
+	assert.Len(t, fssd.listeners[serviceName], 1)
+}
+
+func TestAddListener_KeepsDistinctListenersForSameService(t *testing.T) {
+	serviceName := "com.foo.Bar"
+	fssd := newTestDiscoveryWithWatcher(serviceName)
+	l1 := &registry.ServiceInstancesChangedListener{ServiceName: serviceName}
+	l2 := &registry.ServiceInstancesChangedListener{ServiceName: serviceName}
+
+	assert.NoError(t, fssd.AddListener(l1))
+	assert.NoError(t, fssd.AddListener(l2))
+	assert.Len(t, fssd.listeners[serviceName], 2)
+}
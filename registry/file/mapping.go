@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// MappingListener resolves which applications currently export a given interface, by reading
+// and maintaining the ~/.dubbo/mapping/<interfaceName> file. It is the file-backed equivalent
+// of the interface-app mapping used by the application-level ServiceDiscoveryRegistry.
+type MappingListener struct {
+	mappingPath string
+
+	writeLock sync.Mutex
+	// interfaceLocks serializes the read-modify-write in AddApplicationName per interface
+	// name, so two registrations in this process against the same interface at the same time
+	// can't race and silently drop one another's write. Writes from other processes sharing
+	// the mapping directory are not serialized.
+	interfaceLocks map[string]*sync.Mutex
+}
+
+// newMappingListener builds a MappingListener rooted at rp/.dubbo/mapping.
+func newMappingListener(rp string) *MappingListener {
+	return &MappingListener{
+		mappingPath:    path.Join(rp, ".dubbo", "mapping"),
+		interfaceLocks: make(map[string]*sync.Mutex),
+	}
+}
+
+// lockFor returns the mutex serializing writes for interfaceName, creating it on first use.
+func (l *MappingListener) lockFor(interfaceName string) *sync.Mutex {
+	l.writeLock.Lock()
+	defer l.writeLock.Unlock()
+
+	mu, ok := l.interfaceLocks[interfaceName]
+	if !ok {
+		mu = &sync.Mutex{}
+		l.interfaceLocks[interfaceName] = mu
+	}
+	return mu
+}
+
+// GetApplicationNames returns the applications currently mapped to interfaceName, or an empty
+// slice if no application has published a mapping for it yet.
+func (l *MappingListener) GetApplicationNames(interfaceName string) ([]string, error) {
+	content, err := ioutil.ReadFile(path.Join(l.mappingPath, interfaceName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, perrors.WithStack(err)
+	}
+
+	var apps []string
+	if err := json.Unmarshal(content, &apps); err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	return apps, nil
+}
+
+// AddApplicationName appends applicationName to the mapping file for interfaceName, if it is
+// not already present. The read-modify-write against that file is serialized per
+// interfaceName so concurrent registrations in this process for the same interface don't race.
+func (l *MappingListener) AddApplicationName(interfaceName string, applicationName string) error {
+	mu := l.lockFor(interfaceName)
+	mu.Lock()
+	defer mu.Unlock()
+
+	apps, err := l.GetApplicationNames(interfaceName)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range apps {
+		if a == applicationName {
+			return nil
+		}
+	}
+	apps = append(apps, applicationName)
+
+	content, err := json.Marshal(apps)
+	if err != nil {
+		return perrors.WithStack(err)
+	}
+
+	if err := os.MkdirAll(l.mappingPath, 0755); err != nil {
+		return perrors.WithStack(err)
+	}
+
+	return ioutil.WriteFile(path.Join(l.mappingPath, interfaceName), content, 0644)
+}
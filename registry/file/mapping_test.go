@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMappingListener_AddAndGetApplicationNames(t *testing.T) {
+	rp, err := ioutil.TempDir("", "dubbo-mapping-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(rp)
+
+	l := newMappingListener(rp)
+
+	apps, err := l.GetApplicationNames("com.foo.Bar")
+	assert.NoError(t, err)
+	assert.Empty(t, apps)
+
+	assert.NoError(t, l.AddApplicationName("com.foo.Bar", "app1"))
+	assert.NoError(t, l.AddApplicationName("com.foo.Bar", "app2"))
+	// re-adding an already-present name is a no-op
+	assert.NoError(t, l.AddApplicationName("com.foo.Bar", "app1"))
+
+	apps, err = l.GetApplicationNames("com.foo.Bar")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"app1", "app2"}, apps)
+}
+
+// TestMappingListener_AddApplicationName_ConcurrentWritersDontRace guards the per-interface
+// locking in lockFor: without it, concurrent read-modify-write cycles against the same
+// interface's mapping file can silently drop one another's application name.
+func TestMappingListener_AddApplicationName_ConcurrentWritersDontRace(t *testing.T) {
+	rp, err := ioutil.TempDir("", "dubbo-mapping-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(rp)
+
+	l := newMappingListener(rp)
+
+	const n = 20
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, l.AddApplicationName("com.foo.Bar", "app"+strconv.Itoa(i)))
+		}(i)
+	}
+	wg.Wait()
+
+	apps, err := l.GetApplicationNames("com.foo.Bar")
+	assert.NoError(t, err)
+	assert.Len(t, apps, n)
+}
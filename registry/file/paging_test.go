@@ -0,0 +1,50 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePageParams(t *testing.T) {
+	tests := []struct {
+		name           string
+		offset         int
+		pageSize       int
+		expectedOffset int
+		expectedSize   int
+	}{
+		{"both positive", 2, 10, 2, 10},
+		{"negative offset", -5, 10, 0, 10},
+		{"negative pageSize", 2, -5, 2, 0},
+		{"both negative", -1, -1, 0, 0},
+		{"zero values", 0, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, pageSize := normalizePageParams(tt.offset, tt.pageSize)
+			assert.Equal(t, tt.expectedOffset, offset)
+			assert.Equal(t, tt.expectedSize, pageSize)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned by Register when adding the instance would exceed the
+// configured per-registry instance quota or per-service churn-rate threshold.
+type ErrQuotaExceeded struct {
+	ServiceName string
+	Reason      string
+}
+
+// nolint
+func (e *ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("quota exceeded for service{%s}: %s", e.ServiceName, e.Reason)
+}
+
+// changeEvent is a single add/remove observed for a service, used to compute ChangeRate.
+type changeEvent struct {
+	at time.Time
+}
+
+// eventCounter tracks, per service name, the current instance count and the add/remove
+// churn over a sliding window. It is borrowed from the counter/event idea used by
+// service-center's quota plugin, sized down to what the file registry needs.
+type eventCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	events map[string][]changeEvent
+
+	// rateThreshold is the max allowed events per second for a single service; <= 0 disables
+	// the check.
+	rateThreshold float64
+}
+
+func newEventCounter(rateThreshold float64) *eventCounter {
+	return &eventCounter{
+		counts:        make(map[string]int),
+		events:        make(map[string][]changeEvent),
+		rateThreshold: rateThreshold,
+	}
+}
+
+// InstanceCount returns the number of instances currently tracked for serviceName.
+func (c *eventCounter) InstanceCount(serviceName string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[serviceName]
+}
+
+// TotalInstanceCount returns the number of instances tracked across every service.
+func (c *eventCounter) TotalInstanceCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	total := 0
+	for _, n := range c.counts {
+		total += n
+	}
+	return total
+}
+
+// ChangeRate returns the add/remove churn observed for serviceName over the trailing
+// window, expressed as events per second. Events older than the window are discarded.
+func (c *eventCounter) ChangeRate(serviceName string, window time.Duration) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if window <= 0 {
+		return 0
+	}
+
+	cutoff := time.Now().Add(-window)
+	kept := c.events[serviceName][:0]
+	for _, e := range c.events[serviceName] {
+		if e.at.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	c.events[serviceName] = kept
+
+	return float64(len(kept)) / window.Seconds()
+}
+
+// seed sets the initial instance count for serviceName without recording a churn event,
+// e.g. when the counter is primed from the instances already on disk at startup.
+func (c *eventCounter) seed(serviceName string, count int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[serviceName] = count
+}
+
+// recordAdd registers an instance add for serviceName, from either a local Register call or
+// a remote fsnotify event.
+func (c *eventCounter) recordAdd(serviceName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[serviceName]++
+	c.events[serviceName] = append(c.events[serviceName], changeEvent{at: time.Now()})
+}
+
+// recordRemove registers an instance removal for serviceName, from either a local Unregister
+// call or a remote fsnotify event.
+func (c *eventCounter) recordRemove(serviceName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts[serviceName] > 0 {
+		c.counts[serviceName]--
+	}
+	c.events[serviceName] = append(c.events[serviceName], changeEvent{at: time.Now()})
+}
+
+// wouldExceedRate reports whether serviceName's churn over the trailing window has already
+// reached rateThreshold.
+func (c *eventCounter) wouldExceedRate(serviceName string, window time.Duration) bool {
+	if c.rateThreshold <= 0 {
+		return false
+	}
+	return c.ChangeRate(serviceName, window) >= c.rateThreshold
+}
@@ -0,0 +1,90 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventCounter_InstanceCount(t *testing.T) {
+	c := newEventCounter(0)
+	c.recordAdd("s1")
+	c.recordAdd("s1")
+	c.recordAdd("s2")
+	assert.Equal(t, 2, c.InstanceCount("s1"))
+	assert.Equal(t, 1, c.InstanceCount("s2"))
+	assert.Equal(t, 3, c.TotalInstanceCount())
+
+	c.recordRemove("s1")
+	assert.Equal(t, 1, c.InstanceCount("s1"))
+	assert.Equal(t, 2, c.TotalInstanceCount())
+}
+
+func TestEventCounter_RecordRemove_NeverGoesNegative(t *testing.T) {
+	c := newEventCounter(0)
+	c.recordRemove("s1")
+	assert.Equal(t, 0, c.InstanceCount("s1"))
+}
+
+func TestEventCounter_Seed(t *testing.T) {
+	c := newEventCounter(0)
+	c.seed("s1", 5)
+	assert.Equal(t, 5, c.InstanceCount("s1"))
+	assert.Equal(t, 5, c.TotalInstanceCount())
+}
+
+func TestEventCounter_WouldExceedRate(t *testing.T) {
+	c := newEventCounter(3)
+	assert.False(t, c.wouldExceedRate("s1", time.Second))
+
+	c.recordAdd("s1")
+	c.recordAdd("s1")
+	c.recordAdd("s1")
+	// 3 events over a 1s window is 3 events/second, which reaches the threshold
+	assert.True(t, c.wouldExceedRate("s1", time.Second))
+}
+
+func TestEventCounter_WouldExceedRate_Disabled(t *testing.T) {
+	c := newEventCounter(0)
+	for i := 0; i < 100; i++ {
+		c.recordAdd("s1")
+	}
+	assert.False(t, c.wouldExceedRate("s1", time.Minute))
+}
+
+func TestCheckQuota_ChurnRateExceeded(t *testing.T) {
+	// 2 events over changeRateWindow (10s) is 0.2 events/second, above the 0.1 threshold
+	fssd := &fileSystemServiceDiscovery{counter: newEventCounter(0.1)}
+	fssd.counter.recordAdd("s1")
+	fssd.counter.recordAdd("s1")
+
+	err := fssd.checkQuota("s1")
+	assert.Error(t, err)
+	_, ok := err.(*ErrQuotaExceeded)
+	assert.True(t, ok)
+}
+
+func TestCheckQuota_WithinThreshold(t *testing.T) {
+	fssd := &fileSystemServiceDiscovery{counter: newEventCounter(0)}
+	assert.NoError(t, fssd.checkQuota("s1"))
+}
@@ -25,6 +25,7 @@ import (
 	"path"
 	"strconv"
 	"sync"
+	"time"
 )
 
 import (
@@ -35,7 +36,9 @@ import (
 	"github.com/apache/dubbo-go/config"
 	"github.com/apache/dubbo-go/config_center"
 	"github.com/apache/dubbo-go/config_center/file"
+	"github.com/apache/dubbo-go/metrics"
 	"github.com/apache/dubbo-go/registry"
+	"github.com/apache/dubbo-go/remoting"
 )
 
 import (
@@ -44,10 +47,29 @@ import (
 	perrors "github.com/pkg/errors"
 )
 
+const (
+	// defaultPageSize is used when the discovery URL does not override it via pageSizeParamName
+	defaultPageSize = 100
+	// pageSizeParamName is the discovery URL parameter used to tune GetDefaultPageSize
+	pageSizeParamName = "file.discovery.page.size"
+	// changeRateParamName is the discovery URL parameter used to tune the per-service churn
+	// threshold (events/second) enforced by eventCounter; unset or <= 0 disables the check
+	changeRateParamName = "file.discovery.max.change.rate"
+	// changeRateWindow is the sliding window ChangeRate/wouldExceedRate compute the churn over
+	changeRateWindow = 10 * time.Second
+	// metricsAddrParamName is the discovery URL parameter that, if set, starts the Prometheus
+	// exporter and admin dump endpoint (see metrics.StartServer) listening at that address;
+	// unset leaves the metrics subsystem unstarted.
+	metricsAddrParamName = "file.discovery.metrics.addr"
+)
+
 var (
 	// 16 would be enough. We won't use concurrentMap because in most cases, there are not race condition
 	instanceMap = make(map[string]registry.ServiceDiscovery, 16)
 	initLock    sync.Mutex
+	// metricsServerOnce guards metrics.StartServer: every fileSystemServiceDiscovery name
+	// shares the one process-wide metrics HTTP server instead of each racing to bind its own.
+	metricsServerOnce sync.Once
 )
 
 // init will put the service discovery into extension
@@ -60,6 +82,30 @@ type fileSystemServiceDiscovery struct {
 	dynamicConfiguration file.FileSystemDynamicConfiguration
 	rootPath             string
 	fileMap              map[string]string
+	defaultPageSize      int
+
+	listenerLock sync.Mutex
+	// listeners holds the ServiceInstancesChangedListener registered for each service name
+	listeners map[string][]*registry.ServiceInstancesChangedListener
+	// groupWatchers holds the single fsnotify-backed watcher subscribed per service name
+	groupWatchers map[string]*RegistryConfigurationListener
+
+	// mapping resolves interfaceName -> []applicationName for the application-level
+	// discovery model
+	mapping *MappingListener
+
+	// counter tracks per-service instance counts and churn for Register's quota check
+	counter *eventCounter
+
+	// metricsManager, when non-nil, receives a MetricTypeInstanceCount sample every time a
+	// service's instance count changes, so it can be reported through metrics.StartServer.
+	metricsManager metrics.MetricsManager
+
+	pendingLock sync.Mutex
+	// pendingLocal marks (serviceName, instance id) pairs that were just written by a local
+	// Register/Unregister call, so the fsnotify echo that PublishConfig/RemoveConfig triggers
+	// through RegistryConfigurationListener.Process doesn't count the same change twice.
+	pendingLocal map[string]map[string]struct{}
 }
 
 func newFileSystemServiceDiscovery(name string) (registry.ServiceDiscovery, error) {
@@ -89,13 +135,31 @@ func newFileSystemServiceDiscovery(name string) (registry.ServiceDiscovery, erro
 		p := path.Join(rp, ".dubbo", "registry")
 		url, _ := common.NewURL("")
 		url.AddParamAvoidNil(file.CONFIG_CENTER_DIR_PARAM_NAME, p)
+		// carry the operator-configured params (e.g. pageSizeParamName, changeRateParamName)
+		// from the service-discovery config onto the URL so they're actually lookupable below
+		for k, v := range sdc.Params {
+			url.AddParamAvoidNil(k, v)
+		}
 		if c, err := fdcf.GetDynamicConfiguration(&url); err != nil {
 			return nil, perrors.New("could not find the config for name: " + name)
 		} else {
+			rateThreshold := 0.0
+			if v := url.GetParam(changeRateParamName, ""); v != "" {
+				if parsed, perr := strconv.ParseFloat(v, 64); perr == nil {
+					rateThreshold = parsed
+				}
+			}
+
 			sd := &fileSystemServiceDiscovery{
 				dynamicConfiguration: *c.(*file.FileSystemDynamicConfiguration),
 				rootPath:             p,
 				fileMap:              make(map[string]string),
+				defaultPageSize:      url.GetParamInt(pageSizeParamName, defaultPageSize),
+				listeners:            make(map[string][]*registry.ServiceInstancesChangedListener),
+				groupWatchers:        make(map[string]*RegistryConfigurationListener),
+				mapping:              newMappingListener(rp),
+				counter:              newEventCounter(rateThreshold),
+				pendingLocal:         make(map[string]map[string]struct{}),
 			}
 
 			extension.AddCustomShutdownCallback(func() {
@@ -103,13 +167,27 @@ func newFileSystemServiceDiscovery(name string) (registry.ServiceDiscovery, erro
 			})
 
 			for _, v := range sd.GetServices().Values() {
-				for _, i := range sd.GetInstances(v.(string)) {
-					// like java do nothing
-					l := &RegistryConfigurationListener{}
-					sd.dynamicConfiguration.AddListener(getServiceInstanceId(i), l, config_center.WithGroup(getServiceName(i)))
+				name := v.(string)
+				sd.counter.seed(name, len(sd.GetInstances(name)))
+			}
+
+			if mm, err := metrics.GetMetricsManager(config.GetMetricConfig().GetMetricManagerName()); err != nil {
+				logger.Errorf("[FileServiceDiscovery] Could not init the metrics manager, error = err{%v}", err)
+			} else {
+				sd.metricsManager = mm
+				if addr := url.GetParam(metricsAddrParamName, ""); addr != "" {
+					metricsServerOnce.Do(func() {
+						if _, _, err := metrics.StartServer(addr, mm, sd); err != nil {
+							logger.Errorf("[FileServiceDiscovery] Could not start the metrics server on addr{%s}, error = err{%v}",
+								addr, err)
+						}
+					})
 				}
 			}
 
+			// instances are watched lazily: the fsnotify subscription for a service
+			// group is only set up once a ServiceInstancesChangedListener is
+			// registered for it through AddListener
 			return sd, nil
 		}
 	}
@@ -120,9 +198,37 @@ func (fssd *fileSystemServiceDiscovery) String() string {
 	return fmt.Sprintf("file-system-service-discovery")
 }
 
+// GetMapping returns the MappingListener used to resolve interfaceName -> []applicationName
+// for the application-level discovery model.
+func (fssd *fileSystemServiceDiscovery) GetMapping() *MappingListener {
+	return fssd.mapping
+}
+
+// InstanceCount returns the number of instances currently tracked for serviceName.
+func (fssd *fileSystemServiceDiscovery) InstanceCount(serviceName string) int {
+	return fssd.counter.InstanceCount(serviceName)
+}
+
+// ChangeRate returns the add/remove churn rate (events per second) observed for serviceName
+// over window.
+func (fssd *fileSystemServiceDiscovery) ChangeRate(serviceName string, window time.Duration) float64 {
+	return fssd.counter.ChangeRate(serviceName, window)
+}
+
 // Destroy will destroy the service discovery.
 // If the discovery cannot be destroy, it will return an error.
 func (fssd *fileSystemServiceDiscovery) Destroy() error {
+	fssd.listenerLock.Lock()
+	for serviceName, cl := range fssd.groupWatchers {
+		if err := fssd.dynamicConfiguration.RemoveListener(serviceName, cl, config_center.WithGroup(serviceName)); err != nil {
+			logger.Errorf("[FileServiceDiscovery] Could not remove the watcher for service{%s}, error = err{%v}",
+				serviceName, err)
+		}
+	}
+	fssd.listeners = make(map[string][]*registry.ServiceInstancesChangedListener)
+	fssd.groupWatchers = make(map[string]*RegistryConfigurationListener)
+	fssd.listenerLock.Unlock()
+
 	fssd.dynamicConfiguration.Close()
 
 	for _, f := range fssd.fileMap {
@@ -143,13 +249,105 @@ func (fssd *fileSystemServiceDiscovery) releaseAndRemoveRegistrationFiles(file s
 func (fssd *fileSystemServiceDiscovery) Register(instance registry.ServiceInstance) error {
 	id := getServiceInstanceId(instance)
 	sn := getServiceName(instance)
+
+	// a republish of an id we already hold a file for is a metadata/health-status refresh
+	// (e.g. a heartbeat via Update), not a new instance - don't quota-check or count it again
+	_, isNewInstance := fssd.fileMap[id]
+	isNewInstance = !isNewInstance
+
+	if isNewInstance {
+		if err := fssd.checkQuota(sn); err != nil {
+			return err
+		}
+	}
+
 	if c, err := getContent(instance); err != nil {
 		return err
 	} else {
+		fssd.markPendingLocalChange(sn, id)
 		if err := fssd.dynamicConfiguration.PublishConfig(id, sn, c); err != nil {
 			return perrors.WithStack(err)
 		} else {
 			fssd.fileMap[id] = fssd.dynamicConfiguration.GetPath(id, sn)
+			if isNewInstance {
+				fssd.counter.recordAdd(sn)
+				fssd.reportInstanceCount(sn)
+			}
+		}
+	}
+
+	return nil
+}
+
+// reportInstanceCount feeds serviceName's current instance count into the configured
+// MetricsManager as a MetricTypeInstanceCount sample, if one was set up successfully.
+func (fssd *fileSystemServiceDiscovery) reportInstanceCount(serviceName string) {
+	if fssd.metricsManager == nil {
+		return
+	}
+	fssd.metricsManager.Register(metrics.Metric{
+		ServiceName: serviceName,
+		Type:        metrics.MetricTypeInstanceCount,
+		Value:       float64(fssd.counter.InstanceCount(serviceName)),
+		Timestamp:   time.Now(),
+	})
+}
+
+// markPendingLocalChange records that id under serviceName was just touched by a local
+// Register/Unregister call, but only when a RegistryConfigurationListener is actually
+// watching that service group - otherwise no echo will ever arrive to consume the marker
+// and it would leak.
+func (fssd *fileSystemServiceDiscovery) markPendingLocalChange(serviceName string, id string) {
+	fssd.listenerLock.Lock()
+	_, watched := fssd.groupWatchers[serviceName]
+	fssd.listenerLock.Unlock()
+	if !watched {
+		return
+	}
+
+	fssd.pendingLock.Lock()
+	defer fssd.pendingLock.Unlock()
+	ids, ok := fssd.pendingLocal[serviceName]
+	if !ok {
+		ids = make(map[string]struct{})
+		fssd.pendingLocal[serviceName] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+// consumePendingLocalChange reports whether id under serviceName was just touched by a local
+// Register/Unregister call. It clears the marker so that a later, genuinely remote change
+// with the same id is counted normally.
+func (fssd *fileSystemServiceDiscovery) consumePendingLocalChange(serviceName string, id string) bool {
+	fssd.pendingLock.Lock()
+	defer fssd.pendingLock.Unlock()
+
+	ids, ok := fssd.pendingLocal[serviceName]
+	if !ok {
+		return false
+	}
+	if _, ok := ids[id]; !ok {
+		return false
+	}
+	delete(ids, id)
+	return true
+}
+
+// checkQuota rejects a Register for serviceName with ErrQuotaExceeded when it would push the
+// total tracked instance count past MetricConfig.MaxMetricCountPerRegistry, or when
+// serviceName's churn rate has already crossed the configured threshold.
+func (fssd *fileSystemServiceDiscovery) checkQuota(serviceName string) error {
+	if max := config.GetMetricConfig().GetMaxMetricCountPerRegistry(); fssd.counter.TotalInstanceCount() >= max {
+		return &ErrQuotaExceeded{
+			ServiceName: serviceName,
+			Reason:      fmt.Sprintf("total instance count would exceed max_metric_count_per_registry{%d}", max),
+		}
+	}
+
+	if fssd.counter.wouldExceedRate(serviceName, changeRateWindow) {
+		return &ErrQuotaExceeded{
+			ServiceName: serviceName,
+			Reason:      fmt.Sprintf("change rate exceeded the configured threshold over the last %s", changeRateWindow),
 		}
 	}
 
@@ -184,14 +382,78 @@ func (fssd *fileSystemServiceDiscovery) Update(instance registry.ServiceInstance
 	return fssd.Register(instance)
 }
 
+// metadataDir is the group under the registry root path that PublishMetadata/GetMetadata
+// publish to, keyed by application name and then revision.
+const metadataDir = "metadata"
+
+// revisionMetadataKey is the ServiceInstance.Metadata key carrying the revision of the
+// application's exported-service metadata document, as written by PublishMetadata.
+const revisionMetadataKey = "revision"
+
+// GetMetadata returns the exported-service metadata document published by application app
+// at revision.
+func (fssd *fileSystemServiceDiscovery) GetMetadata(app string, revision string) (string, error) {
+	p, err := fssd.dynamicConfiguration.GetProperties(revision, config_center.WithGroup(metadataDir+"/"+app))
+	if err != nil {
+		return "", perrors.WithStack(err)
+	}
+	return p, nil
+}
+
+// PublishMetadata publishes the exported-service metadata document for application app at
+// revision, so that instances referencing that revision can be hydrated by GetInstances.
+func (fssd *fileSystemServiceDiscovery) PublishMetadata(app string, revision string, metadata string) error {
+	if err := fssd.dynamicConfiguration.PublishConfig(revision, metadataDir+"/"+app, metadata); err != nil {
+		return perrors.WithStack(err)
+	}
+	return nil
+}
+
+// hydrateMetadata loads the revision-keyed metadata document published via PublishMetadata
+// and merges it into instance's Metadata, so application-level consumers can route by
+// interface without a second round trip.
+func (fssd *fileSystemServiceDiscovery) hydrateMetadata(instance *registry.DefaultServiceInstance) {
+	revision, ok := instance.Metadata[revisionMetadataKey]
+	if !ok || revision == "" {
+		return
+	}
+
+	appName := getServiceName(instance)
+	raw, err := fssd.GetMetadata(appName, revision)
+	if err != nil {
+		logger.Errorf("[FileServiceDiscovery] Could not load metadata for app{%s}, revision{%s}, error = err{%v}",
+			appName, revision, err)
+		return
+	}
+
+	var exported map[string]string
+	if err := json.Unmarshal([]byte(raw), &exported); err != nil {
+		logger.Errorf("[FileServiceDiscovery] Could not unmarshal metadata for app{%s}, revision{%s}, error = err{%v}",
+			appName, revision, err)
+		return
+	}
+
+	if instance.Metadata == nil {
+		instance.Metadata = make(map[string]string, len(exported))
+	}
+	for k, v := range exported {
+		instance.Metadata[k] = v
+	}
+}
+
 // Unregister will unregister this instance from registry
 func (fssd *fileSystemServiceDiscovery) Unregister(instance registry.ServiceInstance) error {
 	id := getServiceInstanceId(instance)
 	sn := getServiceName(instance)
+	fssd.markPendingLocalChange(sn, id)
 	if err := fssd.dynamicConfiguration.RemoveConfig(id, sn); err != nil {
 		return err
 	} else {
 		delete(fssd.fileMap, instance.GetId())
+		// Register keys fileMap by getServiceInstanceId, which falls back to host.port
+		delete(fssd.fileMap, id)
+		fssd.counter.recordRemove(sn)
+		fssd.reportInstanceCount(sn)
 		return nil
 	}
 }
@@ -199,7 +461,10 @@ func (fssd *fileSystemServiceDiscovery) Unregister(instance registry.ServiceInst
 // ----------------- discovery -------------------
 // GetDefaultPageSize will return the default page size
 func (fssd *fileSystemServiceDiscovery) GetDefaultPageSize() int {
-	return 100
+	if fssd.defaultPageSize <= 0 {
+		return defaultPageSize
+	}
+	return fssd.defaultPageSize
 }
 
 // GetServices will return the all service names.
@@ -209,7 +474,8 @@ func (fssd *fileSystemServiceDiscovery) GetServices() *gxset.HashSet {
 	fileInfo, _ := ioutil.ReadDir(fssd.dynamicConfiguration.RootPath())
 
 	for _, file := range fileInfo {
-		if file.IsDir() {
+		// the metadata directory holds PublishMetadata documents, not service groups
+		if file.IsDir() && file.Name() != metadataDir {
 			r.Add(file.Name())
 		}
 	}
@@ -239,6 +505,7 @@ func (fssd *fileSystemServiceDiscovery) GetInstances(serviceName string) []regis
 						"error = err{%v} ",
 						id, serviceName, err)
 				} else {
+					fssd.hydrateMetadata(dsi)
 					si = append(si, dsi)
 				}
 			}
@@ -251,26 +518,84 @@ func (fssd *fileSystemServiceDiscovery) GetInstances(serviceName string) []regis
 // GetInstancesByPage will return a page containing instances of ServiceInstance with the serviceName
 // the page will start at offset
 func (fssd *fileSystemServiceDiscovery) GetInstancesByPage(serviceName string, offset int, pageSize int) gxpage.Pager {
-	return nil
+	offset, pageSize = normalizePageParams(offset, pageSize)
+
+	all := fssd.GetInstances(serviceName)
+	res := make([]interface{}, 0, pageSize)
+	for i := offset; i < len(all) && i < offset+pageSize; i++ {
+		res = append(res, all[i])
+	}
+	return gxpage.New(offset, pageSize, res, len(all))
+}
+
+// normalizePageParams clamps offset/pageSize to non-negative values so a caller-supplied
+// negative offset or pageSize can't index a slice out of range or pass a negative capacity
+// to make().
+func normalizePageParams(offset int, pageSize int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if pageSize < 0 {
+		pageSize = 0
+	}
+	return offset, pageSize
 }
 
 // GetHealthyInstancesByPage will return a page containing instances of ServiceInstance.
 // The param healthy indices that the instance should be healthy or not.
 // The page will start at offset
 func (fssd *fileSystemServiceDiscovery) GetHealthyInstancesByPage(serviceName string, offset int, pageSize int, healthy bool) gxpage.Pager {
-	return nil
+	offset, pageSize = normalizePageParams(offset, pageSize)
+
+	all := fssd.GetInstances(serviceName)
+	filtered := make([]registry.ServiceInstance, 0, len(all))
+	for _, i := range all {
+		if i.IsHealthy() == healthy {
+			filtered = append(filtered, i)
+		}
+	}
+
+	res := make([]interface{}, 0, pageSize)
+	for i := offset; i < len(filtered) && i < offset+pageSize; i++ {
+		res = append(res, filtered[i])
+	}
+	return gxpage.New(offset, pageSize, res, len(filtered))
 }
 
 // Batch get all instances by the specified service names
 func (fssd *fileSystemServiceDiscovery) GetRequestInstances(serviceNames []string, offset int, requestedSize int) map[string]gxpage.Pager {
-	return nil
+	res := make(map[string]gxpage.Pager, len(serviceNames))
+	for _, name := range serviceNames {
+		res[name] = fssd.GetInstancesByPage(name, offset, requestedSize)
+	}
+	return res
 }
 
 // ----------------- event ----------------------
-// AddListener adds a new ServiceInstancesChangedListener
-// client
+// AddListener adds a new ServiceInstancesChangedListener. The first listener registered
+// for a given service name subscribes to the underlying FileSystemDynamicConfiguration
+// group; later listeners for the same service name reuse that subscription.
 func (fssd *fileSystemServiceDiscovery) AddListener(listener *registry.ServiceInstancesChangedListener) error {
-	//fssd.dynamicConfiguration.AddListener(listener.ServiceName)
+	fssd.listenerLock.Lock()
+	defer fssd.listenerLock.Unlock()
+
+	serviceName := listener.ServiceName
+	for _, l := range fssd.listeners[serviceName] {
+		if l == listener {
+			// already subscribed
+			return nil
+		}
+	}
+
+	if _, ok := fssd.groupWatchers[serviceName]; !ok {
+		cl := &RegistryConfigurationListener{serviceDiscovery: fssd, serviceName: serviceName}
+		if err := fssd.dynamicConfiguration.AddListener(serviceName, cl, config_center.WithGroup(serviceName)); err != nil {
+			return perrors.WithStack(err)
+		}
+		fssd.groupWatchers[serviceName] = cl
+	}
+
+	fssd.listeners[serviceName] = append(fssd.listeners[serviceName], listener)
 	return nil
 }
 
@@ -288,4 +613,31 @@ func (fssd *fileSystemServiceDiscovery) DispatchEventForInstances(serviceName st
 func (fssd *fileSystemServiceDiscovery) DispatchEvent(event *registry.ServiceInstancesChangedEvent) error {
 	extension.GetGlobalDispatcher().Dispatch(event)
 	return nil
-}
\ No newline at end of file
+}
+
+// RegistryConfigurationListener watches the config center group backing a single service
+// name and reloads/dispatches the instance list whenever a child is added, changed or removed.
+type RegistryConfigurationListener struct {
+	serviceDiscovery *fileSystemServiceDiscovery
+	serviceName      string
+}
+
+// Process handles the config change event fired by the fsnotify watcher that backs the group
+func (l *RegistryConfigurationListener) Process(event *config_center.ConfigChangeEvent) {
+	// a local Register/Unregister already updated the counter for this id; this event is just
+	// the fsnotify echo of that same write, so don't count it again
+	if !l.serviceDiscovery.consumePendingLocalChange(l.serviceName, event.Key) {
+		switch event.ConfigType {
+		case remoting.EventTypeAdd:
+			l.serviceDiscovery.counter.recordAdd(l.serviceName)
+		case remoting.EventTypeDel:
+			l.serviceDiscovery.counter.recordRemove(l.serviceName)
+		}
+		l.serviceDiscovery.reportInstanceCount(l.serviceName)
+	}
+
+	if err := l.serviceDiscovery.DispatchEventByServiceName(l.serviceName); err != nil {
+		logger.Errorf("[FileServiceDiscovery] Could not dispatch the event for service{%s}, error = err{%v}",
+			l.serviceName, err)
+	}
+}